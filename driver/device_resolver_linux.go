@@ -0,0 +1,137 @@
+//go:build linux
+
+package driver
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sysBlockPath is a var rather than a const so tests can point it at a fake sysfs tree.
+var sysBlockPath = "/sys/block"
+
+const (
+	deviceResolveMaxAttempts    = 6
+	deviceResolveInitialBackoff = 100 * time.Millisecond
+	deviceResolveMaxBackoff     = 3 * time.Second
+)
+
+// defaultByIDPrefixes are the /dev/disk/by-id prefixes Scaleway has used for attached block
+// volumes over time: scsi-0SCW_b_ssd_volume- on older SCSI-attached instance types, and
+// nvme-SCW_b_ssd_volume- on newer NVMe block storage. Used when newDiskUtils is not given an
+// explicit prefix list, e.g. by a `--device-id-prefixes` driver flag, so clusters running on
+// instance types with yet another naming scheme don't need a code change.
+var defaultByIDPrefixes = []string{diskSCWPrefix, "nvme-SCW_b_ssd_volume-"}
+
+// DeviceResolver locates the host device path for a Scaleway volume ID. Resolve returns
+// errDeviceNotYetAttached when the device legitimately might still be in the process of being
+// attached (so the caller can retry); any other error is permanent.
+type DeviceResolver interface {
+	Resolve(volumeID string) (string, error)
+}
+
+// byIDResolver walks a configurable, ordered list of /dev/disk/by-id prefixes, returning the first
+// symlink that both exists and resolves to a real block device.
+type byIDResolver struct {
+	prefixes []string
+}
+
+func (r byIDResolver) Resolve(volumeID string) (string, error) {
+	for _, prefix := range r.prefixes {
+		devicePath := path.Join(diskByIDPath, prefix+volumeID)
+		realDevicePath, err := filepath.EvalSymlinks(devicePath)
+		if err != nil {
+			continue
+		}
+
+		if isDevice, err := isBlockDevicePath(realDevicePath); err == nil && isDevice {
+			return devicePath, nil
+		}
+	}
+	return "", errDeviceNotYetAttached
+}
+
+// sysBlockResolver falls back to /sys/block/*/serial and /sys/block/*/device/wwid when no by-id
+// symlink has been created yet, or the by-id naming scheme doesn't match any configured prefix.
+// Both files are matched by substring since Scaleway embeds the volume ID inside a longer serial.
+type sysBlockResolver struct{}
+
+func (sysBlockResolver) Resolve(volumeID string) (string, error) {
+	entries, err := os.ReadDir(sysBlockPath)
+	if os.IsNotExist(err) {
+		return "", errDeviceNotYetAttached
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		for _, attr := range []string{"serial", "device/wwid"} {
+			content, err := os.ReadFile(path.Join(sysBlockPath, name, attr))
+			if err != nil {
+				continue
+			}
+			if strings.Contains(string(content), volumeID) {
+				return path.Join("/dev", name), nil
+			}
+		}
+	}
+	return "", errDeviceNotYetAttached
+}
+
+// deviceResolverChain tries each resolver in turn, returning the first device path any of them
+// finds. A resolver reporting errDeviceNotYetAttached just means "try the next strategy"; any other
+// error is permanent and is returned immediately instead of being masked by the next resolver.
+type deviceResolverChain []DeviceResolver
+
+func (c deviceResolverChain) Resolve(volumeID string) (string, error) {
+	for _, resolver := range c {
+		devicePath, err := resolver.Resolve(volumeID)
+		if err == nil {
+			return devicePath, nil
+		}
+		if err != errDeviceNotYetAttached {
+			return "", err
+		}
+	}
+	return "", errDeviceNotYetAttached
+}
+
+// resolveDeviceWithRetry polls resolver with a bounded exponential backoff, to ride out the race
+// between ControllerPublishVolume attaching the volume and udev creating its by-id symlink.
+func resolveDeviceWithRetry(resolver DeviceResolver, volumeID string) (string, error) {
+	backoff := deviceResolveInitialBackoff
+	for attempt := 0; attempt < deviceResolveMaxAttempts; attempt++ {
+		devicePath, err := resolver.Resolve(volumeID)
+		if err == nil {
+			return devicePath, nil
+		}
+		if err != errDeviceNotYetAttached {
+			return "", err
+		}
+
+		if attempt == deviceResolveMaxAttempts-1 {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > deviceResolveMaxBackoff {
+			backoff = deviceResolveMaxBackoff
+		}
+	}
+	return "", errDeviceNotYetAttached
+}
+
+func isBlockDevicePath(realPath string) (bool, error) {
+	deviceInfo, err := os.Stat(realPath)
+	if err != nil {
+		return false, err
+	}
+
+	deviceMode := deviceInfo.Mode()
+	return os.ModeDevice == deviceMode&os.ModeDevice && os.ModeCharDevice != deviceMode&os.ModeCharDevice, nil
+}
@@ -0,0 +1,82 @@
+//go:build linux
+
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSysBlockResolverMatchesSerialSubstring(t *testing.T) {
+	sysBlock := t.TempDir()
+	devName := "nvme0n1"
+	if err := os.MkdirAll(filepath.Join(sysBlock, devName, "device"), 0o755); err != nil {
+		t.Fatalf("failed to set up fake sysfs tree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sysBlock, devName, "device", "wwid"), []byte("nvme.1bc7-scw-vol-1234\n"), 0o644); err != nil {
+		t.Fatalf("failed to write fake wwid: %v", err)
+	}
+
+	orig := sysBlockPathForTest(sysBlock)
+	defer orig()
+
+	got, err := sysBlockResolver{}.Resolve("vol-1234")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	want := filepath.Join("/dev", devName)
+	if got != want {
+		t.Errorf("Resolve() = %q, want %q", got, want)
+	}
+}
+
+func TestSysBlockResolverReturnsNotYetAttachedWhenNoMatch(t *testing.T) {
+	sysBlock := t.TempDir()
+	orig := sysBlockPathForTest(sysBlock)
+	defer orig()
+
+	_, err := sysBlockResolver{}.Resolve("vol-missing")
+	if err != errDeviceNotYetAttached {
+		t.Errorf("Resolve() error = %v, want errDeviceNotYetAttached", err)
+	}
+}
+
+func TestResolveDeviceWithRetrySucceedsAfterTransientFailures(t *testing.T) {
+	attempts := 0
+	resolver := deviceResolverFunc(func(volumeID string) (string, error) {
+		attempts++
+		if attempts < 3 {
+			return "", errDeviceNotYetAttached
+		}
+		return "/dev/fake", nil
+	})
+
+	got, err := resolveDeviceWithRetry(resolver, "vol-1234")
+	if err != nil {
+		t.Fatalf("resolveDeviceWithRetry returned error: %v", err)
+	}
+	if got != "/dev/fake" {
+		t.Errorf("resolveDeviceWithRetry() = %q, want /dev/fake", got)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+// deviceResolverFunc adapts a plain function to the DeviceResolver interface for tests.
+type deviceResolverFunc func(volumeID string) (string, error)
+
+func (f deviceResolverFunc) Resolve(volumeID string) (string, error) {
+	return f(volumeID)
+}
+
+// sysBlockPathForTest points sysBlockPath at dir for the duration of a test, returning a func that
+// restores the original value.
+func sysBlockPathForTest(dir string) func() {
+	orig := sysBlockPath
+	sysBlockPath = dir
+	return func() {
+		sysBlockPath = orig
+	}
+}
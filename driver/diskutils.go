@@ -2,37 +2,19 @@ package driver
 
 import (
 	"fmt"
-	"os"
-	"os/exec"
-	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
-
-	"github.com/docker/docker/pkg/mount"
-	"golang.org/x/sys/unix"
-	"k8s.io/klog"
-	utilsio "k8s.io/utils/io"
 )
 
-const (
-	diskByIDPath  = "/dev/disk/by-id"
-	diskSCWPrefix = "scsi-0SCW_b_ssd_volume-"
-
-	defaultFSType = "ext4"
-
-	procMountInfoMaxListTries             = 3
-	procMountsExpectedNumFieldsPerLine    = 6
-	procMountInfoExpectedAtLeastNumFields = 10
-	procMountsPath                        = "/proc/mounts"
-	procMountInfoPath                     = "/proc/self/mountinfo"
-	expectedAtLeastNumFieldsPerMountInfo  = 10
-)
+const defaultFSType = "ext4"
 
+// DiskUtils is the platform-specific surface the node driver needs to format, mount and resize
+// Scaleway block volumes. Each supported GOOS provides its own implementation behind this
+// interface so that nodeServer compiles (and behaves) identically everywhere.
 type DiskUtils interface {
 	// FormatAndMount tries to mount `devicePath` on `targetPath` as `fsType` with `mountOptions`
-	// If it fails it will try to format `devicePath` as `fsType` first and retry
-	FormatAndMount(targetPath string, devicePath string, fsType string, mountOptions []string) error
+	// If it fails it will try to format `devicePath` as `fsType` with `formatOptions` first and retry
+	FormatAndMount(targetPath string, devicePath string, fsType string, mountOptions []string, formatOptions FormatOptions) error
 
 	// MountToTarget tries to mount `sourcePath` on `targetPath` as `fsType` with `mountOptions`
 	MountToTarget(sourcePath, targetPath, fsType string, mountOptions []string) error
@@ -47,159 +29,35 @@ type DiskUtils interface {
 	IsSharedMounted(targetPath string, devicePath string) (bool, error)
 
 	// GetMountInfo returns a mount informations for `targetPath`
-	// taken from https://github.com/kubernetes/kubernetes/blob/master/pkg/util/mount/mount_linux.go
 	GetMountInfo(targetPath string) (*mountInfo, error)
 
-	// GetStatfs return the statfs struct for the given path
-	GetStatfs(path string) (*unix.Statfs_t, error)
-}
-
-type diskUtils struct{}
-
-func newDiskUtils() *diskUtils {
-	return &diskUtils{}
-}
-
-func (d *diskUtils) FormatAndMount(targetPath string, devicePath string, fsType string, mountOptions []string) error {
-	if fsType == "" {
-		fsType = defaultFSType
-	}
-
-	klog.V(4).Infof("Attempting to mount %s on %s with type %s", devicePath, targetPath, fsType)
-	err := d.MountToTarget(devicePath, targetPath, fsType, mountOptions)
-	if err != nil {
-		klog.V(4).Infof("Mount attempt failed, trying to format device %s with type %s", devicePath, fsType)
-		realFsType, fsErr := d.getDeviceType(devicePath)
-		if fsErr != nil {
-			return fsErr
-		}
-
-		if realFsType == "" {
-			fsErr = d.formatDevice(devicePath, fsType)
-			if fsErr != nil {
-				return fsErr
-			}
-			return d.MountToTarget(devicePath, targetPath, fsType, mountOptions)
-		}
-		return err
-	}
-	return nil
-}
-
-func (d *diskUtils) MountToTarget(sourcePath, targetPath, fsType string, mountOptions []string) error {
-	if fsType == "" {
-		fsType = defaultFSType
-	}
-
-	return mount.Mount(sourcePath, targetPath, fsType, strings.Join(mountOptions, ","))
-}
-
-func (d *diskUtils) formatDevice(devicePath string, fsType string) error {
-	if fsType == "" {
-		fsType = defaultFSType
-	}
-
-	mkfsPath, err := exec.LookPath("mkfs." + fsType)
-	if err != nil {
-		return err
-	}
-
-	mkfsArgs := []string{"-m", "0", devicePath}
-	return exec.Command(mkfsPath, mkfsArgs...).Run()
-}
-
-func (d *diskUtils) getDeviceType(devicePath string) (string, error) {
-	blkidPath, err := exec.LookPath("blkid")
-	if err != nil {
-		return "", err
-	}
-
-	blkidArgs := []string{"-p", "-s", "TYPE", "-s", "PTTYPE", "-o", "export", devicePath}
-	blkidOutputBytes, err := exec.Command(blkidPath, blkidArgs...).Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			if exitErr.ExitCode() == 2 {
-				// From man page of blkid:
-				// If the specified token was not found, or no (specified) devices
-				// could be identified, or it is impossible to gather
-				// any information about the device identifiers
-				// or device content an exit code of 2 is returned.
-				return "", nil
-			}
-		}
-		return "", err
-	}
-
-	blkidOutput := string(blkidOutputBytes)
-	blkidOutputLines := strings.Split(blkidOutput, "\n")
-	for _, blkidLine := range blkidOutputLines {
-		if len(blkidLine) == 0 {
-			continue
-		}
-
-		blkidLineSplit := strings.Split(blkidLine, "=")
-		if blkidLineSplit[0] == "TYPE" && len(blkidLineSplit[1]) > 0 {
-			return blkidLineSplit[1], nil
-		}
-	}
-	// TODO real error???
-	return "", nil
-}
-
-func (d *diskUtils) GetDevicePath(volumeID string) (string, error) {
-	devicePath := path.Join(diskByIDPath, diskSCWPrefix+volumeID)
-	realDevicePath, err := filepath.EvalSymlinks(devicePath)
-	if err != nil {
-		return "", err
-	}
-
-	deviceInfo, err := os.Stat(realDevicePath)
-	if err != nil {
-		return "", err
-	}
+	// GetStatfs return the statfs information for the given path
+	GetStatfs(path string) (*StatfsResult, error)
 
-	deviceMode := deviceInfo.Mode()
-	if os.ModeDevice != deviceMode&os.ModeDevice || os.ModeCharDevice == deviceMode&os.ModeCharDevice {
-		return "", errDevicePathIsNotDevice
-	}
+	// ResizeFilesystem resizes the filesystem on `devicePath` mounted at `targetPath` to use all the
+	// available space on the underlying block device. It is a no-op if the filesystem is already
+	// using all the available space.
+	ResizeFilesystem(devicePath string, targetPath string) error
 
-	return devicePath, nil
+	// Mounter returns the Mounter backing this DiskUtils' mount operations, for node server code
+	// that needs lower-level mount-table access (e.g. MakeRShared) than DiskUtils exposes directly.
+	Mounter() Mounter
 }
 
-func (d *diskUtils) IsSharedMounted(targetPath string, devicePath string) (bool, error) {
-	if targetPath == "" {
-		return false, errTargetPathEmpty
-	}
-
-	mountInfo, err := d.GetMountInfo(targetPath)
-	if err != nil {
-		return false, err
-	}
-
-	if mountInfo == nil {
-		return false, nil
-	}
-
-	sharedMounted := false
-	for _, optionalField := range mountInfo.optionalFields {
-		tag := strings.Split(optionalField, ":")
-		if tag != nil && tag[0] == "shared" {
-			sharedMounted = true
-		}
-	}
-	if !sharedMounted {
-		return false, errTargetNotSharedMounter
-	}
-
-	if devicePath != "" && mountInfo.source != devicePath {
-		return false, errTargetNotMounterOnRightDevice
-	}
-
-	return true, nil
+// StatfsResult is a platform-neutral subset of the information reported by the kernel's statfs
+// family of calls (or, on Windows, by CSI-Proxy's GetVolumeStats), just enough for
+// ResizeFilesystem to decide whether a filesystem already spans its underlying block device.
+type StatfsResult struct {
+	// Blocks is the total number of blocks in the filesystem.
+	Blocks uint64
+	// Bavail is the number of blocks available to an unprivileged user.
+	Bavail uint64
+	// Bsize is the size, in bytes, of a filesystem block.
+	Bsize int64
 }
 
-// taken from https://github.com/kubernetes/kubernetes/blob/master/pkg/util/mount/mount_linux.go
-// This represents a single line in /proc/<pid>/mountinfo.
+// mountInfo represents a single mount, populated from whatever mount table mechanism the current
+// platform exposes (/proc/self/mountinfo on Linux, getmntinfo(3) on FreeBSD, ...).
 type mountInfo struct {
 	// Unique ID for the mount (maybe reused after umount).
 	id int
@@ -213,7 +71,8 @@ type mountInfo struct {
 	source string
 	// Mount point, the pathname of the mount point.
 	mountPoint string
-	// Optional fieds, zero or more fields of the form "tag[:value]".
+	// Optional fieds, zero or more fields of the form "tag[:value]". Empty on platforms (e.g.
+	// FreeBSD) that have no concept of shared subtrees.
 	optionalFields []string
 	// The filesystem type in the form "type[.subtype]".
 	fsType string
@@ -223,83 +82,168 @@ type mountInfo struct {
 	superOptions []string
 }
 
-// taken from https://github.com/kubernetes/kubernetes/blob/master/pkg/util/mount/mount_linux.go
-func (d *diskUtils) GetMountInfo(targetPath string) (*mountInfo, error) {
-	content, err := utilsio.ConsistentRead(procMountInfoPath, procMountInfoMaxListTries)
-	if err != nil {
-		return &mountInfo{}, err
-	}
-	contentStr := string(content)
+// FormatOptions carries the fs-specific formatting knobs read off the StorageClass parameters of a
+// CreateVolume request. An empty FormatOptions (the zero value) means "use the per-filesystem
+// defaults".
+type FormatOptions struct {
+	// ExtBlockSize sets the `-b` block size passed to mkfs.ext{2,3,4}.
+	ExtBlockSize string
+	// ExtReservedBlocksPercentage sets the `-m` reserved-blocks-percentage passed to mkfs.ext{2,3,4}.
+	ExtReservedBlocksPercentage string
+	// ExtLazyItableInit controls whether `-E lazy_itable_init=1` is passed to mkfs.ext{2,3,4}.
+	ExtLazyItableInit bool
+	// ExtFeatures lists feature toggles passed as `-O` to mkfs.ext{2,3,4}.
+	ExtFeatures []string
+
+	// XFSBlockSize sets the `-b size=` data block size passed to mkfs.xfs.
+	XFSBlockSize string
+	// XFSInodeSize sets the `-i size=` inode size passed to mkfs.xfs.
+	XFSInodeSize string
+	// XFSSunit sets the `-d su=` stripe unit passed to mkfs.xfs.
+	XFSSunit string
+	// XFSSwidth sets the `-d sw=` stripe width passed to mkfs.xfs.
+	XFSSwidth string
+	// XFSForce controls whether `-f` is passed to mkfs.xfs to overwrite an existing filesystem.
+	XFSForce bool
+
+	// BtrfsDiscard controls whether mkfs.btrfs is allowed to discard blocks at format time. It
+	// defaults to false (passing `--nodiscard`), since network-attached block storage is already
+	// zeroed on attach; set it to opt back into discard.
+	BtrfsDiscard bool
+	// BtrfsChecksum sets the `--csum` checksum algorithm passed to mkfs.btrfs.
+	BtrfsChecksum string
+}
 
-	for _, line := range strings.Split(contentStr, "\n") {
-		if line == "" {
-			// the last split() item is empty string following the last \n
-			continue
-		}
-		// See `man proc` for authoritative description of format of the file.
-		fields := strings.Fields(line)
-		if len(fields) < expectedAtLeastNumFieldsPerMountInfo {
-			return nil, fmt.Errorf("wrong number of fields in (expected at least %d, got %d): %s", expectedAtLeastNumFieldsPerMountInfo, len(fields), line)
-		}
-		if fields[4] != targetPath {
-			continue
-		}
-		id, err := strconv.Atoi(fields[0])
+// Parameter keys a StorageClass can set to populate FormatOptions. The external-provisioner
+// forwards a CreateVolume request's StorageClass parameters verbatim; the controller copies the
+// ones below into the created volume's VolumeContext so they are handed back to NodeStageVolume.
+const (
+	paramExtBlockSize                = "extBlockSize"
+	paramExtReservedBlocksPercentage = "extReservedBlocksPercentage"
+	paramExtLazyItableInit           = "extLazyItableInit"
+	paramExtFeatures                 = "extFeatures"
+
+	paramXFSBlockSize = "xfsBlockSize"
+	paramXFSInodeSize = "xfsInodeSize"
+	paramXFSSunit     = "xfsSunit"
+	paramXFSSwidth    = "xfsSwidth"
+	paramXFSForce     = "xfsForce"
+
+	paramBtrfsDiscard  = "btrfsDiscard"
+	paramBtrfsChecksum = "btrfsChecksum"
+)
+
+// formatOptionsFromParameters builds a FormatOptions out of the subset of volumeContext recognised
+// above, ignoring any other key (NodeStageVolume's volume context also carries CO-internal keys
+// such as "csi.storage.k8s.io/..." pod metadata that are none of DiskUtils' concern). Boolean
+// parameters that fail to parse are reported as errors rather than silently defaulting, so a typo
+// in a StorageClass doesn't silently fall back to "off".
+func formatOptionsFromParameters(volumeContext map[string]string) (FormatOptions, error) {
+	var options FormatOptions
+
+	options.ExtBlockSize = volumeContext[paramExtBlockSize]
+	options.ExtReservedBlocksPercentage = volumeContext[paramExtReservedBlocksPercentage]
+	if v, ok := volumeContext[paramExtLazyItableInit]; ok {
+		parsed, err := strconv.ParseBool(v)
 		if err != nil {
-			return nil, err
+			return FormatOptions{}, fmt.Errorf("invalid %s %q: %w", paramExtLazyItableInit, v, err)
 		}
-		parentID, err := strconv.Atoi(fields[1])
+		options.ExtLazyItableInit = parsed
+	}
+	if v, ok := volumeContext[paramExtFeatures]; ok && v != "" {
+		options.ExtFeatures = strings.Split(v, ",")
+	}
+
+	options.XFSBlockSize = volumeContext[paramXFSBlockSize]
+	options.XFSInodeSize = volumeContext[paramXFSInodeSize]
+	options.XFSSunit = volumeContext[paramXFSSunit]
+	options.XFSSwidth = volumeContext[paramXFSSwidth]
+	if v, ok := volumeContext[paramXFSForce]; ok {
+		parsed, err := strconv.ParseBool(v)
 		if err != nil {
-			return nil, err
+			return FormatOptions{}, fmt.Errorf("invalid %s %q: %w", paramXFSForce, v, err)
 		}
-		info := &mountInfo{
-			id:           id,
-			parentID:     parentID,
-			majorMinor:   fields[2],
-			root:         fields[3],
-			mountPoint:   fields[4],
-			mountOptions: strings.Split(fields[5], ","),
-		}
-		// All fields until "-" are "optional fields".
-		i := 6
-		for ; i < len(fields) && fields[i] != "-"; i++ {
-			info.optionalFields = append(info.optionalFields, fields[i])
-		}
-		// Parse the rest 3 fields.
-		i++
-		if len(fields)-i < 3 {
-			return nil, fmt.Errorf("expect 3 fields in %s, got %d", line, len(fields)-i)
+		options.XFSForce = parsed
+	}
+
+	if v, ok := volumeContext[paramBtrfsDiscard]; ok {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return FormatOptions{}, fmt.Errorf("invalid %s %q: %w", paramBtrfsDiscard, v, err)
 		}
-		info.fsType = fields[i]
-		info.source = fields[i+1]
-		info.superOptions = strings.Split(fields[i+2], ",")
-		return info, nil
+		options.BtrfsDiscard = parsed
 	}
-	return nil, nil
+	options.BtrfsChecksum = volumeContext[paramBtrfsChecksum]
+
+	return options, nil
 }
 
-func (d *diskUtils) IsBlockDevice(path string) (bool, error) {
-	realPath, err := filepath.EvalSymlinks(path)
-	if err != nil {
-		return false, err
-	}
+// mkfsArgsBuilder builds the mkfs.<fsType> argument list for a given device path and set of
+// per-StorageClass format options.
+type mkfsArgsBuilder func(devicePath string, options FormatOptions) []string
+
+// mkfsArgBuilders maps a filesystem type to its argument builder. Filesystems not present here
+// fall back to no extra arguments beyond the device path. Shared across platforms that format
+// these filesystem types with the Linux-compatible e2fsprogs/xfsprogs/btrfs-progs tool names.
+var mkfsArgBuilders = map[string]mkfsArgsBuilder{
+	"ext2":  extMkfsArgs,
+	"ext3":  extMkfsArgs,
+	"ext4":  extMkfsArgs,
+	"xfs":   xfsMkfsArgs,
+	"btrfs": btrfsMkfsArgs,
+}
 
-	deviceInfo, err := os.Stat(realPath)
-	if err != nil {
-		return false, err
+func extMkfsArgs(devicePath string, options FormatOptions) []string {
+	reservedBlocksPercentage := options.ExtReservedBlocksPercentage
+	if reservedBlocksPercentage == "" {
+		reservedBlocksPercentage = "0"
 	}
 
-	deviceMode := deviceInfo.Mode()
-	if os.ModeDevice != deviceMode&os.ModeDevice || os.ModeCharDevice == deviceMode&os.ModeCharDevice {
-		return false, nil
+	args := []string{"-m", reservedBlocksPercentage}
+	if options.ExtBlockSize != "" {
+		args = append(args, "-b", options.ExtBlockSize)
 	}
+	if options.ExtLazyItableInit {
+		args = append(args, "-E", "lazy_itable_init=1")
+	}
+	for _, feature := range options.ExtFeatures {
+		args = append(args, "-O", feature)
+	}
+	return append(args, devicePath)
+}
 
-	return true, nil
-
+func xfsMkfsArgs(devicePath string, options FormatOptions) []string {
+	// -K skips discarding blocks at mkfs time, which is wasted work on network-attached block
+	// storage that Scaleway already zeroes on attach.
+	args := []string{"-K"}
+	if options.XFSForce {
+		args = append(args, "-f")
+	}
+	if options.XFSBlockSize != "" {
+		args = append(args, "-b", "size="+options.XFSBlockSize)
+	}
+	if options.XFSInodeSize != "" {
+		args = append(args, "-i", "size="+options.XFSInodeSize)
+	}
+	// su= and sw= only mean anything together (stripe unit and the number of units spanning the
+	// stripe width); a lone value produces an invalid mkfs.xfs -d argument, so ignore it.
+	if options.XFSSunit != "" && options.XFSSwidth != "" {
+		args = append(args, "-d", "su="+options.XFSSunit+",sw="+options.XFSSwidth)
+	}
+	return append(args, devicePath)
 }
 
-func (d *diskUtils) GetStatfs(path string) (*unix.Statfs_t, error) {
-	fs := &unix.Statfs_t{}
-	err := unix.Statfs(path, fs)
-	return fs, err
+func btrfsMkfsArgs(devicePath string, options FormatOptions) []string {
+	var args []string
+	// --nodiscard is the sensible default, for the same reason as xfs's -K above: network-attached
+	// block storage is already zeroed on attach.
+	if !options.BtrfsDiscard {
+		args = append(args, "--nodiscard")
+	}
+	checksum := options.BtrfsChecksum
+	if checksum == "" {
+		checksum = "crc32c"
+	}
+	args = append(args, "--csum", checksum)
+	return append(args, devicePath)
 }
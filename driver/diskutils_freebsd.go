@@ -0,0 +1,245 @@
+//go:build freebsd
+
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog"
+	utilexec "k8s.io/utils/exec"
+)
+
+const (
+	// devVtbdPath is where bhyve/FreeBSD VirtIO block devices show up; Scaleway's FreeBSD instances
+	// attach volumes as virtio-blk so this is the common case.
+	devVtbdPath = "/dev"
+	// devVtbdPrefix is the device name prefix used by the virtio_blk(4) driver.
+	devVtbdPrefix = "vtbd"
+)
+
+type diskUtils struct {
+	exec    utilexec.Interface
+	mounter Mounter
+}
+
+// newDiskUtils wires up the FreeBSD DiskUtils implementation. byIDPrefixes is accepted for
+// signature parity with the Linux implementation (whose by-id device resolver it configures) but
+// is unused here: FreeBSD volumes are resolved through devVtbdPath instead.
+func newDiskUtils(byIDPrefixes []string) *diskUtils {
+	exec := utilexec.New()
+	return &diskUtils{
+		exec:    exec,
+		mounter: newMounter(exec),
+	}
+}
+
+func (d *diskUtils) FormatAndMount(targetPath string, devicePath string, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+
+	klog.V(4).Infof("Attempting to mount %s on %s with type %s", devicePath, targetPath, fsType)
+	return d.mounter.SafeFormatAndMount(devicePath, targetPath, fsType, mountOptions, formatOptions)
+}
+
+func (d *diskUtils) Mounter() Mounter {
+	return d.mounter
+}
+
+func (d *diskUtils) MountToTarget(sourcePath, targetPath, fsType string, mountOptions []string) error {
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+	return mountFilesystem(d.exec, sourcePath, targetPath, fsType, mountOptions)
+}
+
+// GetDevicePath resolves volumeID to the virtio-blk device FreeBSD attached it as. Scaleway's
+// FreeBSD images don't populate /dev/disk/by-id (that's a devd/GEOM labeling feature Scaleway
+// doesn't configure), so GetDevicePath instead asks camcontrol(8) for the serial number, which
+// Scaleway sets to the volume ID, and falls back to the first unclaimed vtbd* node.
+func (d *diskUtils) GetDevicePath(volumeID string) (string, error) {
+	camcontrolPath, err := d.exec.LookPath("camcontrol")
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(devVtbdPath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, devVtbdPrefix) || strings.ContainsAny(name, "sp") {
+			// skip partitions (vtbd0p1) and slices (vtbd0s1), we only want whole disks
+			continue
+		}
+
+		out, err := d.exec.Command(camcontrolPath, "identify", name, "-v").CombinedOutput()
+		if err != nil {
+			continue
+		}
+		if strings.Contains(string(out), volumeID) {
+			return filepath.Join(devVtbdPath, name), nil
+		}
+	}
+
+	return "", errDevicePathIsNotDevice
+}
+
+func (d *diskUtils) IsSharedMounted(targetPath string, devicePath string) (bool, error) {
+	// FreeBSD has no shared-subtree concept equivalent to Linux's MS_SHARED: nullfs mounts are
+	// already visible wherever the underlying mount is visible, so there is nothing extra to check.
+	if targetPath == "" {
+		return false, errTargetPathEmpty
+	}
+
+	mountInfo, err := d.GetMountInfo(targetPath)
+	if err != nil {
+		return false, err
+	}
+	if mountInfo == nil {
+		return false, nil
+	}
+	if devicePath != "" && mountInfo.source != devicePath {
+		return false, errTargetNotMounterOnRightDevice
+	}
+	return true, nil
+}
+
+// GetMountInfo finds the mount at targetPath via getfsstat(2), which FreeBSD returns in one shot
+// instead of requiring a /proc/self/mountinfo-style text file.
+func (d *diskUtils) GetMountInfo(targetPath string) (*mountInfo, error) {
+	stats, err := getfsstat()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stat := range stats {
+		if stat.mountPoint == targetPath {
+			return &stat, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *diskUtils) IsBlockDevice(path string) (bool, error) {
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+
+	deviceInfo, err := os.Stat(realPath)
+	if err != nil {
+		return false, err
+	}
+
+	deviceMode := deviceInfo.Mode()
+	if os.ModeDevice != deviceMode&os.ModeDevice || os.ModeCharDevice == deviceMode&os.ModeCharDevice {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (d *diskUtils) GetStatfs(path string) (*StatfsResult, error) {
+	fs := &unix.Statfs_t{}
+	if err := unix.Statfs(path, fs); err != nil {
+		return nil, err
+	}
+	return &StatfsResult{Blocks: fs.Blocks, Bavail: fs.Bavail, Bsize: int64(fs.Bsize)}, nil
+}
+
+// ResizeFilesystem grows the filesystem on devicePath/targetPath. UFS volumes are resized with
+// growfs(8) against the raw device; ext4 volumes formatted via the ports mkfs.ext4 fall back to
+// resize2fs, same as on Linux.
+func (d *diskUtils) ResizeFilesystem(devicePath string, targetPath string) error {
+	fsType, err := getDeviceType(d.exec, devicePath)
+	if err != nil {
+		return errResizeFilesystemFailed{err}
+	}
+
+	switch fsType {
+	case "ufs":
+		growfsPath, err := d.exec.LookPath("growfs")
+		if err != nil {
+			return errResizeFilesystemFailed{err}
+		}
+		if out, err := d.exec.Command(growfsPath, "-y", devicePath).CombinedOutput(); err != nil {
+			return errResizeFilesystemFailed{fmt.Errorf("growfs failed: %w, output: %s", err, out)}
+		}
+	case "ext2", "ext3", "ext4":
+		resize2fsPath, err := d.exec.LookPath("resize2fs")
+		if err != nil {
+			return errResizeFilesystemFailed{err}
+		}
+		if out, err := d.exec.Command(resize2fsPath, devicePath).CombinedOutput(); err != nil {
+			return errResizeFilesystemFailed{fmt.Errorf("resize2fs failed: %w, output: %s", err, out)}
+		}
+	default:
+		return errUnsupportedFilesystem{fsType}
+	}
+	return nil
+}
+
+// formatDevice formats devicePath with fsType, using newfs(8)/newfs_ufs(8) for FreeBSD's native UFS
+// and falling back to the e2fsprogs mkfs.ext4 available from ports for parity with Linux-created
+// volumes.
+func formatDevice(exec utilexec.Interface, devicePath string, fsType string, options FormatOptions) error {
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+
+	if fsType == "ufs" {
+		newfsPath, err := exec.LookPath("newfs")
+		if err != nil {
+			return err
+		}
+		out, err := exec.Command(newfsPath, devicePath).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("newfs failed: %w, output: %s", err, out)
+		}
+		return nil
+	}
+
+	mkfsPath, err := exec.LookPath("mkfs." + fsType)
+	if err != nil {
+		return err
+	}
+
+	argsBuilder, ok := mkfsArgBuilders[fsType]
+	var mkfsArgs []string
+	if ok {
+		mkfsArgs = argsBuilder(devicePath, options)
+	} else {
+		mkfsArgs = []string{devicePath}
+	}
+
+	out, err := exec.Command(mkfsPath, mkfsArgs...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("mkfs.%s failed: %w, output: %s", fsType, err, out)
+	}
+	return nil
+}
+
+// getDeviceType shells out to fstyp(8), FreeBSD's equivalent of blkid, to identify the filesystem
+// already present on devicePath, if any.
+func getDeviceType(exec utilexec.Interface, devicePath string) (string, error) {
+	fstypPath, err := exec.LookPath("fstyp")
+	if err != nil {
+		return "", err
+	}
+
+	out, err := exec.Command(fstypPath, devicePath).CombinedOutput()
+	if err != nil {
+		if exitErr, ok := err.(utilexec.ExitError); ok && exitErr.ExitStatus() == 1 {
+			// fstyp exits 1 when it cannot recognize the filesystem, mirroring blkid's exit code 2.
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
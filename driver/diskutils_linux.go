@@ -0,0 +1,351 @@
+//go:build linux
+
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/mount"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog"
+	utilexec "k8s.io/utils/exec"
+	utilsio "k8s.io/utils/io"
+)
+
+const (
+	diskByIDPath  = "/dev/disk/by-id"
+	diskSCWPrefix = "scsi-0SCW_b_ssd_volume-"
+
+	procMountInfoMaxListTries             = 3
+	procMountsExpectedNumFieldsPerLine    = 6
+	procMountInfoExpectedAtLeastNumFields = 10
+	procMountsPath                        = "/proc/mounts"
+	procMountInfoPath                     = "/proc/self/mountinfo"
+	expectedAtLeastNumFieldsPerMountInfo  = 10
+)
+
+type diskUtils struct {
+	exec           utilexec.Interface
+	mounter        Mounter
+	deviceResolver DeviceResolver
+}
+
+// newDiskUtils wires up the Linux DiskUtils implementation. byIDPrefixes, if non-empty, overrides
+// defaultByIDPrefixes with the prefix list from the driver's `--device-id-prefixes` flag, letting
+// clusters on instance types with a yet-unsupported by-id naming scheme adapt without a code
+// change.
+func newDiskUtils(byIDPrefixes []string) *diskUtils {
+	if len(byIDPrefixes) == 0 {
+		byIDPrefixes = defaultByIDPrefixes
+	}
+
+	exec := utilexec.New()
+	return &diskUtils{
+		exec:    exec,
+		mounter: newMounter(exec),
+		deviceResolver: deviceResolverChain{
+			byIDResolver{prefixes: byIDPrefixes},
+			sysBlockResolver{},
+		},
+	}
+}
+
+func (d *diskUtils) FormatAndMount(targetPath string, devicePath string, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+
+	klog.V(4).Infof("Attempting to mount %s on %s with type %s", devicePath, targetPath, fsType)
+	return d.mounter.SafeFormatAndMount(devicePath, targetPath, fsType, mountOptions, formatOptions)
+}
+
+func (d *diskUtils) Mounter() Mounter {
+	return d.mounter
+}
+
+func (d *diskUtils) MountToTarget(sourcePath, targetPath, fsType string, mountOptions []string) error {
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+
+	return mount.Mount(sourcePath, targetPath, fsType, strings.Join(mountOptions, ","))
+}
+
+func (d *diskUtils) formatDevice(devicePath string, fsType string, options FormatOptions) error {
+	return formatDevice(d.exec, devicePath, fsType, options)
+}
+
+func formatDevice(exec utilexec.Interface, devicePath string, fsType string, options FormatOptions) error {
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+
+	mkfsPath, err := exec.LookPath("mkfs." + fsType)
+	if err != nil {
+		return err
+	}
+
+	argsBuilder, ok := mkfsArgBuilders[fsType]
+	var mkfsArgs []string
+	if ok {
+		mkfsArgs = argsBuilder(devicePath, options)
+	} else {
+		mkfsArgs = []string{devicePath}
+	}
+
+	out, err := exec.Command(mkfsPath, mkfsArgs...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("mkfs.%s failed: %w, output: %s", fsType, err, out)
+	}
+	return nil
+}
+
+func (d *diskUtils) getDeviceType(devicePath string) (string, error) {
+	return getDeviceType(d.exec, devicePath)
+}
+
+func getDeviceType(exec utilexec.Interface, devicePath string) (string, error) {
+	blkidPath, err := exec.LookPath("blkid")
+	if err != nil {
+		return "", err
+	}
+
+	blkidArgs := []string{"-p", "-s", "TYPE", "-s", "PTTYPE", "-o", "export", devicePath}
+	blkidOutputBytes, err := exec.Command(blkidPath, blkidArgs...).Output()
+	if err != nil {
+		if exitErr, ok := err.(utilexec.ExitError); ok {
+			if exitErr.ExitStatus() == 2 {
+				// From man page of blkid:
+				// If the specified token was not found, or no (specified) devices
+				// could be identified, or it is impossible to gather
+				// any information about the device identifiers
+				// or device content an exit code of 2 is returned.
+				return "", nil
+			}
+		}
+		return "", err
+	}
+
+	blkidOutput := string(blkidOutputBytes)
+	blkidOutputLines := strings.Split(blkidOutput, "\n")
+	for _, blkidLine := range blkidOutputLines {
+		if len(blkidLine) == 0 {
+			continue
+		}
+
+		blkidLineSplit := strings.Split(blkidLine, "=")
+		if blkidLineSplit[0] == "TYPE" && len(blkidLineSplit[1]) > 0 {
+			return blkidLineSplit[1], nil
+		}
+	}
+	// TODO real error???
+	return "", nil
+}
+
+// GetDevicePath resolves volumeID to a host device path via d.deviceResolver, polling with a
+// bounded backoff to ride out the race between ControllerPublishVolume attaching the volume and
+// udev creating its by-id symlink.
+func (d *diskUtils) GetDevicePath(volumeID string) (string, error) {
+	return resolveDeviceWithRetry(d.deviceResolver, volumeID)
+}
+
+func (d *diskUtils) IsSharedMounted(targetPath string, devicePath string) (bool, error) {
+	if targetPath == "" {
+		return false, errTargetPathEmpty
+	}
+
+	mountInfo, err := d.GetMountInfo(targetPath)
+	if err != nil {
+		return false, err
+	}
+
+	if mountInfo == nil {
+		return false, nil
+	}
+
+	sharedMounted := false
+	for _, optionalField := range mountInfo.optionalFields {
+		tag := strings.Split(optionalField, ":")
+		if tag != nil && tag[0] == "shared" {
+			sharedMounted = true
+		}
+	}
+	if !sharedMounted {
+		return false, errTargetNotSharedMounter
+	}
+
+	if devicePath != "" && mountInfo.source != devicePath {
+		return false, errTargetNotMounterOnRightDevice
+	}
+
+	return true, nil
+}
+
+// taken from https://github.com/kubernetes/kubernetes/blob/master/pkg/util/mount/mount_linux.go
+func (d *diskUtils) GetMountInfo(targetPath string) (*mountInfo, error) {
+	return getMountInfo(targetPath)
+}
+
+// taken from https://github.com/kubernetes/kubernetes/blob/master/pkg/util/mount/mount_linux.go
+// This represents a single line in /proc/<pid>/mountinfo.
+func getMountInfo(targetPath string) (*mountInfo, error) {
+	content, err := utilsio.ConsistentRead(procMountInfoPath, procMountInfoMaxListTries)
+	if err != nil {
+		return &mountInfo{}, err
+	}
+	contentStr := string(content)
+
+	for _, line := range strings.Split(contentStr, "\n") {
+		if line == "" {
+			// the last split() item is empty string following the last \n
+			continue
+		}
+		// See `man proc` for authoritative description of format of the file.
+		fields := strings.Fields(line)
+		if len(fields) < expectedAtLeastNumFieldsPerMountInfo {
+			return nil, fmt.Errorf("wrong number of fields in (expected at least %d, got %d): %s", expectedAtLeastNumFieldsPerMountInfo, len(fields), line)
+		}
+		if fields[4] != targetPath {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		parentID, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		info := &mountInfo{
+			id:           id,
+			parentID:     parentID,
+			majorMinor:   fields[2],
+			root:         fields[3],
+			mountPoint:   fields[4],
+			mountOptions: strings.Split(fields[5], ","),
+		}
+		// All fields until "-" are "optional fields".
+		i := 6
+		for ; i < len(fields) && fields[i] != "-"; i++ {
+			info.optionalFields = append(info.optionalFields, fields[i])
+		}
+		// Parse the rest 3 fields.
+		i++
+		if len(fields)-i < 3 {
+			return nil, fmt.Errorf("expect 3 fields in %s, got %d", line, len(fields)-i)
+		}
+		info.fsType = fields[i]
+		info.source = fields[i+1]
+		info.superOptions = strings.Split(fields[i+2], ",")
+		return info, nil
+	}
+	return nil, nil
+}
+
+func (d *diskUtils) IsBlockDevice(path string) (bool, error) {
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+
+	return isBlockDevicePath(realPath)
+}
+
+func (d *diskUtils) GetStatfs(path string) (*StatfsResult, error) {
+	fs := &unix.Statfs_t{}
+	if err := unix.Statfs(path, fs); err != nil {
+		return nil, err
+	}
+	return &StatfsResult{Blocks: fs.Blocks, Bavail: fs.Bavail, Bsize: int64(fs.Bsize)}, nil
+}
+
+// resizeFilesystemTolerancePercent is how close a filesystem's reported size has to be to its
+// block device's size, as a percentage, for isFilesystemAtDeviceSize to consider it already
+// resized.
+const resizeFilesystemTolerancePercent = 1
+
+// ResizeFilesystem resizes the filesystem present on devicePath and mounted at targetPath.
+// It detects the filesystem type with blkid and dispatches to the matching resize tool.
+// The resize is skipped if the filesystem already spans the whole block device.
+func (d *diskUtils) ResizeFilesystem(devicePath string, targetPath string) error {
+	alreadyAtSize, err := d.isFilesystemAtDeviceSize(devicePath, targetPath)
+	if err != nil {
+		return err
+	}
+	if alreadyAtSize {
+		klog.V(4).Infof("Filesystem on %s already spans the full block device, skipping resize", devicePath)
+		return nil
+	}
+
+	fsType, err := d.getDeviceType(devicePath)
+	if err != nil {
+		return errResizeFilesystemFailed{err}
+	}
+
+	switch fsType {
+	case "ext2", "ext3", "ext4":
+		resize2fsPath, err := d.exec.LookPath("resize2fs")
+		if err != nil {
+			return errResizeFilesystemFailed{err}
+		}
+		if out, err := d.exec.Command(resize2fsPath, devicePath).CombinedOutput(); err != nil {
+			return errResizeFilesystemFailed{fmt.Errorf("resize2fs failed: %w, output: %s", err, out)}
+		}
+	case "xfs":
+		xfsGrowfsPath, err := d.exec.LookPath("xfs_growfs")
+		if err != nil {
+			return errResizeFilesystemFailed{err}
+		}
+		if out, err := d.exec.Command(xfsGrowfsPath, targetPath).CombinedOutput(); err != nil {
+			return errResizeFilesystemFailed{fmt.Errorf("xfs_growfs failed: %w, output: %s", err, out)}
+		}
+	case "btrfs":
+		btrfsPath, err := d.exec.LookPath("btrfs")
+		if err != nil {
+			return errResizeFilesystemFailed{err}
+		}
+		if out, err := d.exec.Command(btrfsPath, "filesystem", "resize", "max", targetPath).CombinedOutput(); err != nil {
+			return errResizeFilesystemFailed{fmt.Errorf("btrfs filesystem resize failed: %w, output: %s", err, out)}
+		}
+	default:
+		return errUnsupportedFilesystem{fsType}
+	}
+
+	return nil
+}
+
+// isFilesystemAtDeviceSize compares the size of the underlying block device with the amount of
+// space statfs reports as available to the filesystem mounted at targetPath, to decide whether a
+// resize is actually necessary.
+func (d *diskUtils) isFilesystemAtDeviceSize(devicePath string, targetPath string) (bool, error) {
+	device, err := os.Open(devicePath)
+	if err != nil {
+		return false, err
+	}
+	defer device.Close()
+
+	deviceSize, err := unix.IoctlGetUint64(int(device.Fd()), unix.BLKGETSIZE64)
+	if err != nil {
+		return false, fmt.Errorf("failed to get size of device %s: %w", devicePath, err)
+	}
+
+	fs, err := d.GetStatfs(targetPath)
+	if err != nil {
+		return false, err
+	}
+	fsSize := uint64(fs.Blocks) * uint64(fs.Bsize)
+
+	// Filesystems reserve metadata blocks and will never report a size exactly equal to the block
+	// device, so treat anything within resizeFilesystemTolerancePercent of the device size as
+	// already resized. A flat one-block tolerance isn't enough here: xfs and btrfs both reserve far
+	// more than one block's worth of metadata, which would make this never trip for them.
+	tolerance := deviceSize * resizeFilesystemTolerancePercent / 100
+	if deviceSize <= fsSize+tolerance {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,172 @@
+//go:build linux
+
+package driver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMkfsArgBuilders(t *testing.T) {
+	tests := []struct {
+		name       string
+		fsType     string
+		devicePath string
+		options    FormatOptions
+		want       []string
+	}{
+		{
+			name:       "ext4 defaults",
+			fsType:     "ext4",
+			devicePath: "/dev/scw-vol",
+			options:    FormatOptions{},
+			want:       []string{"-m", "0", "/dev/scw-vol"},
+		},
+		{
+			name:       "ext4 with tuning",
+			fsType:     "ext4",
+			devicePath: "/dev/scw-vol",
+			options: FormatOptions{
+				ExtReservedBlocksPercentage: "1",
+				ExtBlockSize:                "4096",
+				ExtLazyItableInit:           true,
+				ExtFeatures:                 []string{"^has_journal"},
+			},
+			want: []string{"-m", "1", "-b", "4096", "-E", "lazy_itable_init=1", "-O", "^has_journal", "/dev/scw-vol"},
+		},
+		{
+			name:       "xfs defaults",
+			fsType:     "xfs",
+			devicePath: "/dev/scw-vol",
+			options:    FormatOptions{},
+			want:       []string{"-K", "/dev/scw-vol"},
+		},
+		{
+			name:       "xfs with tuning",
+			fsType:     "xfs",
+			devicePath: "/dev/scw-vol",
+			options: FormatOptions{
+				XFSForce:     true,
+				XFSBlockSize: "4096",
+				XFSInodeSize: "512",
+				XFSSunit:     "128",
+				XFSSwidth:    "256",
+			},
+			want: []string{"-K", "-f", "-b", "size=4096", "-i", "size=512", "-d", "su=128,sw=256", "/dev/scw-vol"},
+		},
+		{
+			name:       "xfs with only sunit set",
+			fsType:     "xfs",
+			devicePath: "/dev/scw-vol",
+			options: FormatOptions{
+				XFSSunit: "128",
+			},
+			want: []string{"-K", "/dev/scw-vol"},
+		},
+		{
+			name:       "btrfs defaults",
+			fsType:     "btrfs",
+			devicePath: "/dev/scw-vol",
+			options:    FormatOptions{},
+			want:       []string{"--nodiscard", "--csum", "crc32c", "/dev/scw-vol"},
+		},
+		{
+			name:       "btrfs with tuning",
+			fsType:     "btrfs",
+			devicePath: "/dev/scw-vol",
+			options: FormatOptions{
+				BtrfsDiscard:  true,
+				BtrfsChecksum: "xxhash",
+			},
+			want: []string{"--csum", "xxhash", "/dev/scw-vol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder, ok := mkfsArgBuilders[tt.fsType]
+			if !ok {
+				t.Fatalf("no mkfs arg builder registered for %s", tt.fsType)
+			}
+			got := builder(tt.devicePath, tt.options)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mkfs args for %s = %v, want %v", tt.fsType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDeviceBuildsExpectedCommand(t *testing.T) {
+	fakeExec, fakeCmd := newFakeExec()
+	d := &diskUtils{exec: fakeExec}
+
+	if err := d.formatDevice("/dev/scw-vol", "xfs", FormatOptions{XFSForce: true}); err != nil {
+		t.Fatalf("formatDevice returned error: %v", err)
+	}
+
+	if fakeCmd.name != "mkfs.xfs" {
+		t.Errorf("expected mkfs.xfs to be looked up and run, got %q", fakeCmd.name)
+	}
+	wantArgs := []string{"-K", "-f", "/dev/scw-vol"}
+	if !reflect.DeepEqual(fakeCmd.args, wantArgs) {
+		t.Errorf("formatDevice args = %v, want %v", fakeCmd.args, wantArgs)
+	}
+}
+
+func TestFormatOptionsFromParameters(t *testing.T) {
+	tests := []struct {
+		name          string
+		volumeContext map[string]string
+		want          FormatOptions
+		wantErr       bool
+	}{
+		{
+			name:          "no recognised keys",
+			volumeContext: map[string]string{"csi.storage.k8s.io/pod.name": "my-pod"},
+			want:          FormatOptions{},
+		},
+		{
+			name: "ext tuning",
+			volumeContext: map[string]string{
+				paramExtBlockSize:                "4096",
+				paramExtReservedBlocksPercentage: "1",
+				paramExtLazyItableInit:           "true",
+				paramExtFeatures:                 "^has_journal,extent",
+			},
+			want: FormatOptions{
+				ExtBlockSize:                "4096",
+				ExtReservedBlocksPercentage: "1",
+				ExtLazyItableInit:           true,
+				ExtFeatures:                 []string{"^has_journal", "extent"},
+			},
+		},
+		{
+			name:          "btrfs discard opt-in",
+			volumeContext: map[string]string{paramBtrfsDiscard: "true", paramBtrfsChecksum: "xxhash"},
+			want:          FormatOptions{BtrfsDiscard: true, BtrfsChecksum: "xxhash"},
+		},
+		{
+			name:          "invalid boolean",
+			volumeContext: map[string]string{paramXFSForce: "not-a-bool"},
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatOptionsFromParameters(tt.volumeContext)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("formatOptionsFromParameters returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("formatOptionsFromParameters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+//go:build !linux && !freebsd && !windows
+
+package driver
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// errUnsupportedPlatform is returned by every DiskUtils/Mounter method on a GOOS this driver has no
+// platform-specific implementation for.
+var errUnsupportedPlatform = fmt.Errorf("scaleway-csi: unsupported platform %s", runtime.GOOS)
+
+type diskUtils struct{}
+
+// newDiskUtils accepts byIDPrefixes for signature parity with the Linux implementation; it is
+// unused here since every DiskUtils method on this platform is a stub.
+func newDiskUtils(byIDPrefixes []string) *diskUtils {
+	return &diskUtils{}
+}
+
+func (d *diskUtils) FormatAndMount(targetPath string, devicePath string, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	return errUnsupportedPlatform
+}
+
+func (d *diskUtils) MountToTarget(sourcePath, targetPath, fsType string, mountOptions []string) error {
+	return errUnsupportedPlatform
+}
+
+func (d *diskUtils) IsBlockDevice(path string) (bool, error) {
+	return false, errUnsupportedPlatform
+}
+
+func (d *diskUtils) GetDevicePath(volumeID string) (string, error) {
+	return "", errUnsupportedPlatform
+}
+
+func (d *diskUtils) IsSharedMounted(targetPath string, devicePath string) (bool, error) {
+	return false, errUnsupportedPlatform
+}
+
+func (d *diskUtils) GetMountInfo(targetPath string) (*mountInfo, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func (d *diskUtils) GetStatfs(path string) (*StatfsResult, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func (d *diskUtils) ResizeFilesystem(devicePath string, targetPath string) error {
+	return errUnsupportedPlatform
+}
+
+func (d *diskUtils) Mounter() Mounter {
+	return unsupportedMounter{}
+}
+
+// unsupportedMounter implements Mounter by returning errUnsupportedPlatform from every method.
+type unsupportedMounter struct{}
+
+func (unsupportedMounter) List() ([]MountPoint, error) {
+	return nil, errUnsupportedPlatform
+}
+
+func (unsupportedMounter) IsLikelyNotMountPoint(file string) (bool, error) {
+	return false, errUnsupportedPlatform
+}
+
+func (unsupportedMounter) IsNotMountPoint(file string) (bool, error) {
+	return false, errUnsupportedPlatform
+}
+
+func (unsupportedMounter) MakeRShared(path string) error {
+	return errUnsupportedPlatform
+}
+
+func (unsupportedMounter) SafeFormatAndMount(source, target, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	return errUnsupportedPlatform
+}
@@ -0,0 +1,158 @@
+//go:build windows
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	diskapi "github.com/kubernetes-csi/csi-proxy/client/api/disk/v1"
+	volumeapi "github.com/kubernetes-csi/csi-proxy/client/api/volume/v1"
+	diskclient "github.com/kubernetes-csi/csi-proxy/client/groups/disk/v1"
+	volumeclient "github.com/kubernetes-csi/csi-proxy/client/groups/volume/v1"
+	"k8s.io/klog"
+)
+
+const ntfsFSType = "ntfs"
+
+// diskUtils talks to the Kubernetes CSI-Proxy, which runs as a privileged Windows service and
+// exposes the disk/volume/filesystem operations kubelet-side code would otherwise need
+// administrator rights (and cgo) to perform directly.
+type diskUtils struct {
+	diskClient   *diskclient.Client
+	volumeClient *volumeclient.Client
+}
+
+// newDiskUtils accepts byIDPrefixes for signature parity with the Linux implementation; it is
+// unused here since device discovery goes through CSI-Proxy's disk IDs rather than
+// /dev/disk/by-id.
+func newDiskUtils(byIDPrefixes []string) *diskUtils {
+	diskClient, err := diskclient.NewClient()
+	if err != nil {
+		klog.Fatalf("failed to create CSI-Proxy disk client: %v", err)
+	}
+	volumeClient, err := volumeclient.NewClient()
+	if err != nil {
+		klog.Fatalf("failed to create CSI-Proxy volume client: %v", err)
+	}
+	return &diskUtils{
+		diskClient:   diskClient,
+		volumeClient: volumeClient,
+	}
+}
+
+// GetDevicePath resolves volumeID to a CSI-Proxy disk number by listing every disk's IDs and
+// matching on the Scaleway serial number, which CSI-Proxy surfaces as one of a disk's SerialNumber
+// or Page83 identifiers depending on the virtual disk controller in use.
+func (d *diskUtils) GetDevicePath(volumeID string) (string, error) {
+	diskIDsResponse, err := d.diskClient.ListDiskIDs(&diskapi.ListDiskIDsRequest{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list disk IDs: %w", err)
+	}
+
+	for diskNumber, diskIDs := range diskIDsResponse.DiskIDs {
+		if strings.Contains(diskIDs.SerialNumber, volumeID) || strings.Contains(diskIDs.Page83, volumeID) {
+			return fmt.Sprintf("%d", diskNumber), nil
+		}
+	}
+
+	return "", errDevicePathIsNotDevice
+}
+
+// FormatAndMount partitions devicePath (a disk number, per GetDevicePath), formats the resulting
+// volume as NTFS and mounts it at targetPath, via the disk.v1/volume.v1 CSI-Proxy API groups.
+func (d *diskUtils) FormatAndMount(targetPath string, devicePath string, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	if fsType == "" {
+		fsType = ntfsFSType
+	}
+
+	klog.V(4).Infof("Partitioning disk %s for volume at %s", devicePath, targetPath)
+	if _, err := d.diskClient.PartitionDisk(&diskapi.PartitionDiskRequest{
+		DiskNumber: devicePath,
+	}); err != nil {
+		return fmt.Errorf("failed to partition disk %s: %w", devicePath, err)
+	}
+
+	volumeIDsResponse, err := d.volumeClient.ListVolumesOnDisk(&volumeapi.ListVolumesOnDiskRequest{
+		DiskNumber: devicePath,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list volumes on disk %s: %w", devicePath, err)
+	}
+	if len(volumeIDsResponse.VolumeIds) == 0 {
+		return fmt.Errorf("no volume found on disk %s after partitioning", devicePath)
+	}
+	volumeID := volumeIDsResponse.VolumeIds[0]
+
+	if _, err := d.volumeClient.FormatVolume(&volumeapi.FormatVolumeRequest{
+		VolumeId: volumeID,
+	}); err != nil {
+		return fmt.Errorf("failed to format volume %s: %w", volumeID, err)
+	}
+
+	if _, err := d.volumeClient.MountVolume(&volumeapi.MountVolumeRequest{
+		VolumeId: volumeID,
+		Path:     targetPath,
+	}); err != nil {
+		return fmt.Errorf("failed to mount volume %s on %s: %w", volumeID, targetPath, err)
+	}
+	return nil
+}
+
+func (d *diskUtils) MountToTarget(sourcePath, targetPath, fsType string, mountOptions []string) error {
+	_, err := d.volumeClient.MountVolume(&volumeapi.MountVolumeRequest{
+		VolumeId: sourcePath,
+		Path:     targetPath,
+	})
+	return err
+}
+
+func (d *diskUtils) IsBlockDevice(path string) (bool, error) {
+	// CSI-Proxy only ever hands back formatted volumes, never raw block devices, to node code.
+	return false, nil
+}
+
+func (d *diskUtils) IsSharedMounted(targetPath string, devicePath string) (bool, error) {
+	// Windows has no bind-mount/shared-subtree propagation model: a CSI-Proxy MountVolume call is
+	// only ever visible within the kubelet/CSI-Proxy session it was made from, so there is nothing
+	// to verify beyond the mount itself having succeeded.
+	return true, nil
+}
+
+func (d *diskUtils) GetMountInfo(targetPath string) (*mountInfo, error) {
+	return nil, nil
+}
+
+// GetStatfs emulates StatfsResult using CSI-Proxy's GetVolumeStats, so the generic resize
+// idempotency check in ResizeFilesystem can run unmodified on Windows.
+func (d *diskUtils) GetStatfs(path string) (*StatfsResult, error) {
+	statsResponse, err := d.volumeClient.GetVolumeStats(&volumeapi.GetVolumeStatsRequest{
+		VolumeId: path,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume stats for %s: %w", path, err)
+	}
+
+	const statfsBlockSize = 4096
+	return &StatfsResult{
+		Bsize:  statfsBlockSize,
+		Blocks: uint64(statsResponse.TotalBytes) / statfsBlockSize,
+		Bavail: uint64(statsResponse.TotalBytes-statsResponse.UsedBytes) / statfsBlockSize,
+	}, nil
+}
+
+// ResizeFilesystem grows volumeID (passed in as devicePath) to fill its underlying disk via
+// CSI-Proxy's ResizeVolume call.
+func (d *diskUtils) ResizeFilesystem(devicePath string, targetPath string) error {
+	_, err := d.volumeClient.ResizeVolume(&volumeapi.ResizeVolumeRequest{
+		VolumeId: devicePath,
+	})
+	if err != nil {
+		return errResizeFilesystemFailed{err}
+	}
+	return nil
+}
+
+func (d *diskUtils) Mounter() Mounter {
+	return &mounter{volumeClient: d.volumeClient}
+}
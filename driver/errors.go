@@ -0,0 +1,34 @@
+package driver
+
+import "fmt"
+
+// errUnsupportedFilesystem is returned when ResizeFilesystem is asked to grow a filesystem type it
+// does not know how to resize. It is a permanent failure: retrying will not help.
+type errUnsupportedFilesystem struct {
+	fsType string
+}
+
+func (e errUnsupportedFilesystem) Error() string {
+	return fmt.Sprintf("unsupported filesystem type for resize: %s", e.fsType)
+}
+
+// errResizeFilesystemFailed wraps a failure encountered while resizing a filesystem. It is treated
+// as retryable, since it usually stems from a transient condition (tool missing from PATH, device
+// momentarily busy) rather than an unsupported operation.
+type errResizeFilesystemFailed struct {
+	err error
+}
+
+func (e errResizeFilesystemFailed) Error() string {
+	return fmt.Sprintf("failed to resize filesystem: %s", e.err)
+}
+
+func (e errResizeFilesystemFailed) Unwrap() error {
+	return e.err
+}
+
+// errDeviceNotYetAttached is returned by GetDevicePath (and the DeviceResolver chain backing it on
+// Linux) when a volume's device node cannot be found yet. It is retryable: the CO is expected to
+// call NodeStageVolume/NodePublishVolume again, by which point the attach/udev race should have
+// resolved itself.
+var errDeviceNotYetAttached = fmt.Errorf("device not yet attached")
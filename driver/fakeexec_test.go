@@ -0,0 +1,37 @@
+//go:build linux
+
+package driver
+
+import (
+	fakeexec "k8s.io/utils/exec/testing"
+)
+
+// recordedCmd captures the last command name and arguments handed to the fake exec.Interface, so
+// tests can assert on mkfs argument construction without shelling out.
+type recordedCmd struct {
+	name string
+	args []string
+}
+
+// newFakeExec returns a fakeexec.FakeExec that resolves LookPath for any command and records the
+// last invocation into the returned recordedCmd.
+func newFakeExec() (*fakeexec.FakeExec, *recordedCmd) {
+	recorded := &recordedCmd{}
+
+	fakeExec := &fakeexec.FakeExec{
+		LookPathFunc: func(cmd string) (string, error) {
+			return cmd, nil
+		},
+	}
+	fakeExec.CommandScript = append(fakeExec.CommandScript, func(cmd string, args ...string) fakeexec.Cmd {
+		recorded.name = cmd
+		recorded.args = args
+		return &fakeexec.FakeCmd{
+			CombinedOutputScript: []fakeexec.FakeCombinedOutputAction{
+				func() ([]byte, error) { return nil, nil },
+			},
+		}
+	})
+
+	return fakeExec, recorded
+}
@@ -0,0 +1,47 @@
+package driver
+
+// fakeMounter implements Mounter with per-method function fields, so node-server tests can drive
+// nodeServer's mount-handling logic without touching the real mount table. A nil function field
+// means the test doesn't expect that method to be called; it panics if invoked.
+type fakeMounter struct {
+	listFunc                  func() ([]MountPoint, error)
+	isLikelyNotMountPointFunc func(file string) (bool, error)
+	isNotMountPointFunc       func(file string) (bool, error)
+	makeRSharedFunc           func(path string) error
+	safeFormatAndMountFunc    func(source, target, fsType string, mountOptions []string, formatOptions FormatOptions) error
+}
+
+func (f *fakeMounter) List() ([]MountPoint, error) {
+	if f.listFunc == nil {
+		panic("fakeMounter: List unexpectedly called")
+	}
+	return f.listFunc()
+}
+
+func (f *fakeMounter) IsLikelyNotMountPoint(file string) (bool, error) {
+	if f.isLikelyNotMountPointFunc == nil {
+		panic("fakeMounter: IsLikelyNotMountPoint unexpectedly called")
+	}
+	return f.isLikelyNotMountPointFunc(file)
+}
+
+func (f *fakeMounter) IsNotMountPoint(file string) (bool, error) {
+	if f.isNotMountPointFunc == nil {
+		panic("fakeMounter: IsNotMountPoint unexpectedly called")
+	}
+	return f.isNotMountPointFunc(file)
+}
+
+func (f *fakeMounter) MakeRShared(path string) error {
+	if f.makeRSharedFunc == nil {
+		panic("fakeMounter: MakeRShared unexpectedly called")
+	}
+	return f.makeRSharedFunc(path)
+}
+
+func (f *fakeMounter) SafeFormatAndMount(source, target, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	if f.safeFormatAndMountFunc == nil {
+		panic("fakeMounter: SafeFormatAndMount unexpectedly called")
+	}
+	return f.safeFormatAndMountFunc(source, target, fsType, mountOptions, formatOptions)
+}
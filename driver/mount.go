@@ -0,0 +1,39 @@
+package driver
+
+// MountPoint represents a single mount, modeled on mount-utils' MountPoint.
+type MountPoint struct {
+	Device string
+	Path   string
+	Type   string
+	Opts   []string
+	Freq   int
+	Pass   int
+}
+
+// Mounter abstracts the raw mount(8)/umount(8) operations needed by the node driver, modeled on
+// k8s.io/mount-utils' Interface. It exists as its own interface (rather than folded into DiskUtils)
+// so it can be faked out in tests without a fake of the whole disk/filesystem surface, and so that
+// each platform can back it with whatever mount table mechanism it actually has.
+type Mounter interface {
+	// List returns every mount point currently known to the kernel.
+	List() ([]MountPoint, error)
+
+	// IsLikelyNotMountPoint reports whether `file` is likely not a mount point, using a cheap
+	// stat-based heuristic. False positives are possible for bind mounts; use IsNotMountPoint when
+	// that distinction matters.
+	IsLikelyNotMountPoint(file string) (bool, error)
+
+	// IsNotMountPoint reports whether `file` is not a mount point, walking the mount table so that
+	// bind mounts (which IsLikelyNotMountPoint cannot see) are detected correctly.
+	IsNotMountPoint(file string) (bool, error)
+
+	// MakeRShared ensures `path` is mounted rshared, bind-mounting it onto itself first if it is not
+	// already part of a shared peer group. This is required so that mounts made inside `path`
+	// (e.g. by the container runtime) propagate back out to the host and other containers. Platforms
+	// without a shared-subtree concept (e.g. FreeBSD) may treat this as a no-op.
+	MakeRShared(path string) error
+
+	// SafeFormatAndMount formats `source` with `fsType` using `formatOptions` if, and only if, it
+	// does not already carry a filesystem signature, then mounts it on `target`.
+	SafeFormatAndMount(source, target, fsType string, mountOptions []string, formatOptions FormatOptions) error
+}
@@ -0,0 +1,142 @@
+//go:build freebsd
+
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	utilexec "k8s.io/utils/exec"
+)
+
+type mounter struct {
+	exec utilexec.Interface
+}
+
+func newMounter(exec utilexec.Interface) *mounter {
+	return &mounter{exec: exec}
+}
+
+// getfsstat wraps getfsstat(2) (via golang.org/x/sys/unix.Getfsstat) to enumerate every mount
+// currently known to the kernel, FreeBSD's equivalent of parsing /proc/self/mountinfo on Linux.
+func getfsstat() ([]mountInfo, error) {
+	n, err := unix.Getfsstat(nil, unix.MNT_NOWAIT)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]unix.Statfs_t, n)
+	if _, err := unix.Getfsstat(buf, unix.MNT_NOWAIT); err != nil {
+		return nil, err
+	}
+
+	mounts := make([]mountInfo, 0, len(buf))
+	for _, stat := range buf {
+		mounts = append(mounts, mountInfo{
+			majorMinor: fmt.Sprintf("%d", stat.Fsid),
+			source:     unix.ByteSliceToString(stat.Mntfromname[:]),
+			mountPoint: unix.ByteSliceToString(stat.Mntonname[:]),
+			fsType:     unix.ByteSliceToString(stat.Fstypename[:]),
+		})
+	}
+	return mounts, nil
+}
+
+func (m *mounter) List() ([]MountPoint, error) {
+	stats, err := getfsstat()
+	if err != nil {
+		return nil, err
+	}
+
+	mountPoints := make([]MountPoint, 0, len(stats))
+	for _, stat := range stats {
+		mountPoints = append(mountPoints, MountPoint{
+			Device: stat.source,
+			Path:   stat.mountPoint,
+			Type:   stat.fsType,
+		})
+	}
+	return mountPoints, nil
+}
+
+func (m *mounter) IsLikelyNotMountPoint(file string) (bool, error) {
+	stat, err := os.Stat(file)
+	if err != nil {
+		return true, err
+	}
+	parentStat, err := os.Stat(filepath.Dir(strings.TrimSuffix(file, "/")))
+	if err != nil {
+		return true, err
+	}
+	return stat.Sys().(*syscall.Stat_t).Dev == parentStat.Sys().(*syscall.Stat_t).Dev, nil
+}
+
+func (m *mounter) IsNotMountPoint(file string) (bool, error) {
+	mountPoints, err := m.List()
+	if err != nil {
+		return true, err
+	}
+	for _, mp := range mountPoints {
+		if mp.Path == file {
+			return false, nil
+		}
+	}
+	return m.IsLikelyNotMountPoint(file)
+}
+
+// MakeRShared is a no-op on FreeBSD: the kernel has no MS_SHARED/rshared propagation model, and
+// nullfs mounts (FreeBSD's bind-mount equivalent) are already visible wherever the underlying
+// mount is visible, so there is no extra propagation step to perform.
+func (m *mounter) MakeRShared(path string) error {
+	return nil
+}
+
+// SafeFormatAndMount mounts source on target, formatting it first with fsType/formatOptions only if
+// it does not already carry a recognized filesystem signature.
+func (m *mounter) SafeFormatAndMount(source, target, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+
+	if err := mountFilesystem(m.exec, source, target, fsType, mountOptions); err != nil {
+		realFsType, fsErr := getDeviceType(m.exec, source)
+		if fsErr != nil {
+			return fsErr
+		}
+
+		if realFsType != "" {
+			return err
+		}
+
+		if fsErr := formatDevice(m.exec, source, fsType, formatOptions); fsErr != nil {
+			return fsErr
+		}
+		return mountFilesystem(m.exec, source, target, fsType, mountOptions)
+	}
+	return nil
+}
+
+// mountFilesystem shells out to mount(8), since FreeBSD has no libc mount(2) wrapper exposed
+// through golang.org/x/sys/unix that matches the docker/pkg/mount convenience used on Linux.
+func mountFilesystem(exec utilexec.Interface, source, target, fsType string, mountOptions []string) error {
+	mountPath, err := exec.LookPath("mount")
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-t", fsType}
+	if len(mountOptions) > 0 {
+		args = append(args, "-o", strings.Join(mountOptions, ","))
+	}
+	args = append(args, source, target)
+
+	out, err := exec.Command(mountPath, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("mount failed: %w, output: %s", err, out)
+	}
+	return nil
+}
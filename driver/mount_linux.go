@@ -0,0 +1,149 @@
+//go:build linux
+
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/pkg/mount"
+	utilexec "k8s.io/utils/exec"
+	utilsio "k8s.io/utils/io"
+)
+
+type mounter struct {
+	exec utilexec.Interface
+}
+
+func newMounter(exec utilexec.Interface) *mounter {
+	return &mounter{exec: exec}
+}
+
+// List parses /proc/mounts, which documents each mount as six whitespace-separated fields:
+// device, path, type, options, freq, pass.
+func (m *mounter) List() ([]MountPoint, error) {
+	content, err := utilsio.ConsistentRead(procMountsPath, procMountInfoMaxListTries)
+	if err != nil {
+		return nil, err
+	}
+
+	var mountPoints []MountPoint
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != procMountsExpectedNumFieldsPerLine {
+			return nil, fmt.Errorf("wrong number of fields in mounts line (expected %d, got %d): %s", procMountsExpectedNumFieldsPerLine, len(fields), line)
+		}
+
+		freq, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return nil, err
+		}
+		pass, err := strconv.Atoi(fields[5])
+		if err != nil {
+			return nil, err
+		}
+
+		mountPoints = append(mountPoints, MountPoint{
+			Device: fields[0],
+			Path:   fields[1],
+			Type:   fields[2],
+			Opts:   strings.Split(fields[3], ","),
+			Freq:   freq,
+			Pass:   pass,
+		})
+	}
+	return mountPoints, nil
+}
+
+// IsLikelyNotMountPoint reports a mount point by comparing the device ID of file with that of its
+// parent directory: a mount point's device differs from its parent's, a plain directory's does not.
+// taken from https://github.com/kubernetes/kubernetes/blob/master/pkg/util/mount/mount_linux.go
+func (m *mounter) IsLikelyNotMountPoint(file string) (bool, error) {
+	stat, err := os.Stat(file)
+	if err != nil {
+		return true, err
+	}
+	parentStat, err := os.Stat(filepath.Dir(strings.TrimSuffix(file, "/")))
+	if err != nil {
+		return true, err
+	}
+	return stat.Sys().(*syscall.Stat_t).Dev == parentStat.Sys().(*syscall.Stat_t).Dev, nil
+}
+
+// IsNotMountPoint walks the mount list so that bind mounts, which share the same device as their
+// parent and so fool IsLikelyNotMountPoint's stat-based heuristic, are still detected.
+func (m *mounter) IsNotMountPoint(file string) (bool, error) {
+	mountPoints, err := m.List()
+	if err != nil {
+		return true, err
+	}
+
+	for _, mp := range mountPoints {
+		if mp.Path == file {
+			return false, nil
+		}
+	}
+	return m.IsLikelyNotMountPoint(file)
+}
+
+// MakeRShared ensures path is part of a shared peer group, bind-mounting it onto itself and
+// marking it rshared if /proc/self/mountinfo shows no `shared:` optional field for it yet.
+func (m *mounter) MakeRShared(path string) error {
+	mountInfo, err := getMountInfo(path)
+	if err != nil {
+		return err
+	}
+
+	if mountInfo != nil {
+		for _, optionalField := range mountInfo.optionalFields {
+			if strings.HasPrefix(optionalField, "shared:") {
+				return nil
+			}
+		}
+	}
+
+	mountPath, err := m.exec.LookPath("mount")
+	if err != nil {
+		return err
+	}
+
+	if out, err := m.exec.Command(mountPath, "--bind", path, path).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to bind mount %s onto itself: %w, output: %s", path, err, out)
+	}
+	if out, err := m.exec.Command(mountPath, "--make-rshared", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to make %s rshared: %w, output: %s", path, err, out)
+	}
+	return nil
+}
+
+// SafeFormatAndMount mounts source on target, formatting it first with fsType/formatOptions only
+// if it does not already carry a recognized filesystem signature.
+func (m *mounter) SafeFormatAndMount(source, target, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+
+	if err := mount.Mount(source, target, fsType, strings.Join(mountOptions, ",")); err != nil {
+		realFsType, fsErr := getDeviceType(m.exec, source)
+		if fsErr != nil {
+			return fsErr
+		}
+
+		if realFsType != "" {
+			return err
+		}
+
+		if fsErr := formatDevice(m.exec, source, fsType, formatOptions); fsErr != nil {
+			return fsErr
+		}
+		return mount.Mount(source, target, fsType, strings.Join(mountOptions, ","))
+	}
+	return nil
+}
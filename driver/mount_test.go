@@ -0,0 +1,41 @@
+//go:build linux
+
+package driver
+
+import (
+	"reflect"
+	"testing"
+
+	fakeexec "k8s.io/utils/exec/testing"
+)
+
+func TestMounterMakeRSharedBindsAndMarksShared(t *testing.T) {
+	var commands [][]string
+
+	fakeExec := &fakeexec.FakeExec{
+		LookPathFunc: func(cmd string) (string, error) { return cmd, nil },
+	}
+	for i := 0; i < 2; i++ {
+		fakeExec.CommandScript = append(fakeExec.CommandScript, func(cmd string, args ...string) fakeexec.Cmd {
+			commands = append(commands, append([]string{cmd}, args...))
+			return &fakeexec.FakeCmd{
+				CombinedOutputScript: []fakeexec.FakeCombinedOutputAction{
+					func() ([]byte, error) { return nil, nil },
+				},
+			}
+		})
+	}
+
+	m := newMounter(fakeExec)
+	if err := m.MakeRShared("/var/lib/kubelet/plugins/scaleway"); err != nil {
+		t.Fatalf("MakeRShared returned error: %v", err)
+	}
+
+	want := [][]string{
+		{"mount", "--bind", "/var/lib/kubelet/plugins/scaleway", "/var/lib/kubelet/plugins/scaleway"},
+		{"mount", "--make-rshared", "/var/lib/kubelet/plugins/scaleway"},
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("MakeRShared commands = %v, want %v", commands, want)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build windows
+
+package driver
+
+import (
+	"fmt"
+
+	volumeapi "github.com/kubernetes-csi/csi-proxy/client/api/volume/v1"
+	volumeclient "github.com/kubernetes-csi/csi-proxy/client/groups/volume/v1"
+)
+
+// mounter backs Mounter with CSI-Proxy's volume.v1 API group. It has no List/mount-table
+// equivalent of /proc/mounts to walk, so IsLikelyNotMountPoint/IsNotMountPoint both fall back to
+// asking CSI-Proxy whether the path is already a mount point directly.
+type mounter struct {
+	volumeClient *volumeclient.Client
+}
+
+func (m *mounter) List() ([]MountPoint, error) {
+	return nil, fmt.Errorf("List is not supported on Windows, use IsNotMountPoint instead")
+}
+
+func (m *mounter) IsLikelyNotMountPoint(file string) (bool, error) {
+	return m.IsNotMountPoint(file)
+}
+
+func (m *mounter) IsNotMountPoint(file string) (bool, error) {
+	response, err := m.volumeClient.IsVolumeMountedToPath(&volumeapi.IsVolumeMountedToPathRequest{
+		Path: file,
+	})
+	if err != nil {
+		return true, err
+	}
+	return !response.Mounted, nil
+}
+
+// MakeRShared is a no-op on Windows: there is no bind-mount/shared-subtree propagation model for
+// CSI-Proxy to configure.
+func (m *mounter) MakeRShared(path string) error {
+	return nil
+}
+
+func (m *mounter) SafeFormatAndMount(source, target, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	notMounted, err := m.IsNotMountPoint(target)
+	if err != nil {
+		return err
+	}
+	if !notMounted {
+		return nil
+	}
+
+	_, err = m.volumeClient.MountVolume(&volumeapi.MountVolumeRequest{
+		VolumeId: source,
+		Path:     target,
+	})
+	return err
+}
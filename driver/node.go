@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"context"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog"
+)
+
+// nodeServer implements the CSI Node service, backed by a DiskUtils implementation responsible for
+// the actual block device and filesystem operations.
+type nodeServer struct {
+	diskUtils DiskUtils
+}
+
+// NodeStageVolume formats (if needed) and mounts devicePath on the node's staging path, applying
+// any fs-specific formatting knobs the StorageClass set via its CreateVolume parameters and
+// forwarded into the volume's context.
+func (n *nodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
+	volumeID := req.GetVolumeId()
+	if volumeID == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeStageVolume volume ID not provided")
+	}
+
+	stagingTargetPath := req.GetStagingTargetPath()
+	if stagingTargetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeStageVolume staging target path not provided")
+	}
+
+	mnt := req.GetVolumeCapability().GetMount()
+	if mnt == nil {
+		return nil, status.Error(codes.InvalidArgument, "NodeStageVolume only supports mount volumes")
+	}
+
+	formatOptions, err := formatOptionsFromParameters(req.GetVolumeContext())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "NodeStageVolume invalid format options: %s", err)
+	}
+
+	devicePath, err := n.diskUtils.GetDevicePath(volumeID)
+	if err == errDeviceNotYetAttached {
+		return nil, status.Errorf(codes.Unavailable, "NodeStageVolume device for volume %s is not attached yet, retry later: %s", volumeID, err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "NodeStageVolume could not find device path for volume %s: %s", volumeID, err)
+	}
+
+	klog.V(4).Infof("Staging %s (device %s) at %s for volume %s", mnt.GetFsType(), devicePath, stagingTargetPath, volumeID)
+	if err := n.diskUtils.FormatAndMount(stagingTargetPath, devicePath, mnt.GetFsType(), mnt.GetMountFlags(), formatOptions); err != nil {
+		return nil, status.Errorf(codes.Internal, "NodeStageVolume failed to format and mount volume %s: %s", volumeID, err)
+	}
+
+	return &csi.NodeStageVolumeResponse{}, nil
+}
+
+// NodeExpandVolume grows the filesystem on an already-mounted volume to match the new size of the
+// underlying block device, as requested by a PVC resize.
+func (n *nodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
+	volumeID := req.GetVolumeId()
+	if volumeID == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeExpandVolume volume ID not provided")
+	}
+
+	volumePath := req.GetVolumePath()
+	if volumePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "NodeExpandVolume volume path not provided")
+	}
+
+	devicePath, err := n.diskUtils.GetDevicePath(volumeID)
+	if err == errDeviceNotYetAttached {
+		return nil, status.Errorf(codes.Unavailable, "NodeExpandVolume device for volume %s is not attached yet, retry later: %s", volumeID, err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "NodeExpandVolume could not find device path for volume %s: %s", volumeID, err)
+	}
+
+	klog.V(4).Infof("Expanding filesystem on %s (device %s) for volume %s", volumePath, devicePath, volumeID)
+	if err := n.diskUtils.ResizeFilesystem(devicePath, volumePath); err != nil {
+		switch err.(type) {
+		case errUnsupportedFilesystem:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		default:
+			return nil, status.Errorf(codes.Internal, "NodeExpandVolume failed to resize filesystem: %s", err)
+		}
+	}
+
+	return &csi.NodeExpandVolumeResponse{}, nil
+}
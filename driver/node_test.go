@@ -0,0 +1,157 @@
+package driver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDiskUtils implements DiskUtils with per-method function fields, mirroring fakeMounter, so
+// nodeServer's error-code mapping can be tested without a real block device. A nil function field
+// means the test doesn't expect that method to be called; it panics if invoked.
+type fakeDiskUtils struct {
+	formatAndMountFunc   func(targetPath, devicePath, fsType string, mountOptions []string, formatOptions FormatOptions) error
+	getDevicePathFunc    func(volumeID string) (string, error)
+	resizeFilesystemFunc func(devicePath, targetPath string) error
+}
+
+func (f *fakeDiskUtils) FormatAndMount(targetPath, devicePath, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+	if f.formatAndMountFunc == nil {
+		panic("fakeDiskUtils: FormatAndMount unexpectedly called")
+	}
+	return f.formatAndMountFunc(targetPath, devicePath, fsType, mountOptions, formatOptions)
+}
+
+func (f *fakeDiskUtils) MountToTarget(sourcePath, targetPath, fsType string, mountOptions []string) error {
+	panic("fakeDiskUtils: MountToTarget unexpectedly called")
+}
+
+func (f *fakeDiskUtils) IsBlockDevice(path string) (bool, error) {
+	panic("fakeDiskUtils: IsBlockDevice unexpectedly called")
+}
+
+func (f *fakeDiskUtils) GetDevicePath(volumeID string) (string, error) {
+	if f.getDevicePathFunc == nil {
+		panic("fakeDiskUtils: GetDevicePath unexpectedly called")
+	}
+	return f.getDevicePathFunc(volumeID)
+}
+
+func (f *fakeDiskUtils) IsSharedMounted(targetPath string, devicePath string) (bool, error) {
+	panic("fakeDiskUtils: IsSharedMounted unexpectedly called")
+}
+
+func (f *fakeDiskUtils) GetMountInfo(targetPath string) (*mountInfo, error) {
+	panic("fakeDiskUtils: GetMountInfo unexpectedly called")
+}
+
+func (f *fakeDiskUtils) GetStatfs(path string) (*StatfsResult, error) {
+	panic("fakeDiskUtils: GetStatfs unexpectedly called")
+}
+
+func (f *fakeDiskUtils) ResizeFilesystem(devicePath string, targetPath string) error {
+	if f.resizeFilesystemFunc == nil {
+		panic("fakeDiskUtils: ResizeFilesystem unexpectedly called")
+	}
+	return f.resizeFilesystemFunc(devicePath, targetPath)
+}
+
+func (f *fakeDiskUtils) Mounter() Mounter {
+	panic("fakeDiskUtils: Mounter unexpectedly called")
+}
+
+func TestNodeExpandVolumeDeviceNotYetAttached(t *testing.T) {
+	n := &nodeServer{diskUtils: &fakeDiskUtils{
+		getDevicePathFunc: func(volumeID string) (string, error) {
+			return "", errDeviceNotYetAttached
+		},
+	}}
+
+	_, err := n.NodeExpandVolume(context.Background(), &csi.NodeExpandVolumeRequest{
+		VolumeId:   "vol-1234",
+		VolumePath: "/var/lib/kubelet/plugins/scaleway/vol-1234",
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("NodeExpandVolume() error = %v, want code %s", err, codes.Unavailable)
+	}
+}
+
+func TestNodeExpandVolumeUnsupportedFilesystem(t *testing.T) {
+	n := &nodeServer{diskUtils: &fakeDiskUtils{
+		getDevicePathFunc: func(volumeID string) (string, error) {
+			return "/dev/fake", nil
+		},
+		resizeFilesystemFunc: func(devicePath, targetPath string) error {
+			return errUnsupportedFilesystem{fsType: "zfs"}
+		},
+	}}
+
+	_, err := n.NodeExpandVolume(context.Background(), &csi.NodeExpandVolumeRequest{
+		VolumeId:   "vol-1234",
+		VolumePath: "/var/lib/kubelet/plugins/scaleway/vol-1234",
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("NodeExpandVolume() error = %v, want code %s", err, codes.InvalidArgument)
+	}
+}
+
+func TestNodeStageVolumeHappyPath(t *testing.T) {
+	var gotSource, gotTarget, gotFSType string
+	var gotMountOptions []string
+	var gotFormatOptions FormatOptions
+
+	mounter := &fakeMounter{
+		safeFormatAndMountFunc: func(source, target, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+			gotSource, gotTarget, gotFSType = source, target, fsType
+			gotMountOptions, gotFormatOptions = mountOptions, formatOptions
+			return nil
+		},
+	}
+
+	n := &nodeServer{diskUtils: &fakeDiskUtils{
+		getDevicePathFunc: func(volumeID string) (string, error) {
+			return "/dev/fake", nil
+		},
+		formatAndMountFunc: func(targetPath, devicePath, fsType string, mountOptions []string, formatOptions FormatOptions) error {
+			return mounter.SafeFormatAndMount(devicePath, targetPath, fsType, mountOptions, formatOptions)
+		},
+	}}
+
+	req := &csi.NodeStageVolumeRequest{
+		VolumeId:          "vol-1234",
+		StagingTargetPath: "/var/lib/kubelet/plugins/scaleway/vol-1234",
+		VolumeCapability: &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{
+				Mount: &csi.VolumeCapability_MountVolume{
+					FsType:     "ext4",
+					MountFlags: []string{"noatime"},
+				},
+			},
+		},
+		VolumeContext: map[string]string{paramExtBlockSize: "4096"},
+	}
+
+	if _, err := n.NodeStageVolume(context.Background(), req); err != nil {
+		t.Fatalf("NodeStageVolume returned error: %v", err)
+	}
+
+	if gotSource != "/dev/fake" {
+		t.Errorf("SafeFormatAndMount source = %q, want /dev/fake", gotSource)
+	}
+	if gotTarget != req.StagingTargetPath {
+		t.Errorf("SafeFormatAndMount target = %q, want %q", gotTarget, req.StagingTargetPath)
+	}
+	if gotFSType != "ext4" {
+		t.Errorf("SafeFormatAndMount fsType = %q, want ext4", gotFSType)
+	}
+	if len(gotMountOptions) != 1 || gotMountOptions[0] != "noatime" {
+		t.Errorf("SafeFormatAndMount mountOptions = %v, want [noatime]", gotMountOptions)
+	}
+	if !reflect.DeepEqual(gotFormatOptions, FormatOptions{ExtBlockSize: "4096"}) {
+		t.Errorf("SafeFormatAndMount formatOptions = %+v, want {ExtBlockSize:4096}", gotFormatOptions)
+	}
+}